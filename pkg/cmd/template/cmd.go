@@ -5,10 +5,14 @@ package template
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
 	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/schema/jsonschema"
+	"github.com/k14s/ytt/pkg/unknowns"
 	"github.com/k14s/ytt/pkg/workspace"
 	"github.com/k14s/ytt/pkg/yamlmeta"
 	"github.com/spf13/cobra"
@@ -25,8 +29,12 @@ type TemplateOptions struct {
 
 	BulkFilesSourceOpts    BulkFilesSourceOpts
 	RegularFilesSourceOpts RegularFilesSourceOpts
+	RemoteFilesSourceOpts  RemoteFilesSourceOpts
 	FileMarksOpts          FileMarksOpts
 	DataValuesFlags        DataValuesFlags
+	EnvironmentFlags       EnvironmentFlags
+	SchemaFlags            SchemaFlags
+	UnknownsFlags          UnknownsFlags
 }
 
 type TemplateInput struct {
@@ -34,9 +42,11 @@ type TemplateInput struct {
 }
 
 type TemplateOutput struct {
-	Files  []files.OutputFile
-	DocSet *yamlmeta.DocumentSet
-	Err    error
+	Files    []files.OutputFile
+	DocSet   *yamlmeta.DocumentSet
+	Raw      []byte
+	Unknowns []unknowns.Finding
+	Err      error
 }
 
 type FileSource interface {
@@ -46,7 +56,7 @@ type FileSource interface {
 	Output(TemplateOutput) error
 }
 
-var _ []FileSource = []FileSource{&BulkFilesSource{}, &RegularFilesSource{}}
+var _ []FileSource = []FileSource{&BulkFilesSource{}, &RegularFilesSource{}, &RemoteFilesSource{}}
 
 func NewOptions() *TemplateOptions {
 	return &TemplateOptions{}
@@ -70,8 +80,12 @@ func NewCmd(o *TemplateOptions) *cobra.Command {
 
 	o.BulkFilesSourceOpts.Set(cmd)
 	o.RegularFilesSourceOpts.Set(cmd)
+	o.RemoteFilesSourceOpts.Set(cmd)
 	o.FileMarksOpts.Set(cmd)
 	o.DataValuesFlags.Set(cmd)
+	o.EnvironmentFlags.Set(cmd)
+	o.SchemaFlags.Set(cmd)
+	o.UnknownsFlags.Set(cmd)
 	return cmd
 }
 
@@ -84,20 +98,41 @@ func (o *TemplateOptions) Run() error {
 	}()
 
 	srcs := []FileSource{
+		NewRemoteFilesSource(o.RegularFilesSourceOpts, o.RemoteFilesSourceOpts, ui),
 		NewBulkFilesSource(o.BulkFilesSourceOpts, ui),
 		NewRegularFilesSource(o.RegularFilesSourceOpts, ui),
 	}
 
-	in, err := o.pickSource(srcs, func(s FileSource) bool { return s.HasInput() }).Input()
+	in, err := mergeInputs(srcs)
 	if err != nil {
 		return err
 	}
 
 	out := o.RunWithFiles(in, ui)
 
+	if handled, err := writeRawOutput(os.Stdout, out); handled {
+		return err
+	}
+
 	return o.pickSource(srcs, func(s FileSource) bool { return s.HasOutput() }).Output(out)
 }
 
+// writeRawOutput writes out.Raw (e.g. the formatted bytes produced by
+// --schema-inspect) directly to w and reports whether out carried a Raw
+// payload at all. The FileSource.Output() implementations only know how to
+// write .Files/.DocSet, so a Raw payload has to bypass that pipeline
+// entirely or it's silently dropped.
+func writeRawOutput(w io.Writer, out TemplateOutput) (bool, error) {
+	if out.Raw == nil {
+		return false, nil
+	}
+	if out.Err != nil {
+		return true, out.Err
+	}
+	_, err := w.Write(out.Raw)
+	return true, err
+}
+
 func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) TemplateOutput {
 	var err error
 
@@ -118,6 +153,17 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 		return TemplateOutput{Err: err}
 	}
 
+	var flagDataValuesOverlay *yamlmeta.Document
+	if len(valuesOverlays) > 0 {
+		flagDataValuesOverlay = valuesOverlays[len(valuesOverlays)-1]
+	}
+
+	environmentOverlay, err := o.EnvironmentFlags.AsOverlays(valuesOverlays)
+	if err != nil {
+		return TemplateOutput{Err: err}
+	}
+	valuesOverlays = insertOverlayBeforeFlagOverlay(valuesOverlays, environmentOverlay)
+
 	libraryExecutionFactory := workspace.NewLibraryExecutionFactory(ui, workspace.TemplateLoaderOpts{
 		IgnoreUnknownComments:   o.IgnoreUnknownComments,
 		ImplicitMapKeyOverrides: o.ImplicitMapKeyOverrides,
@@ -127,14 +173,36 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 	libraryCtx := workspace.LibraryExecutionContext{Current: rootLibrary, Root: rootLibrary}
 	libraryLoader := libraryExecutionFactory.New(libraryCtx)
 
+	if err := o.SchemaFlags.ValidateInspectFormat(); err != nil {
+		return TemplateOutput{Err: err}
+	}
+
 	schemaDocs, err := libraryLoader.Schemas()
 	if err != nil {
 		return TemplateOutput{Err: err}
 	}
 	var schema yamlmeta.Schema = &yamlmeta.AnySchema{}
-	if len(schemaDocs) > 0 {
+	var effectiveSchemaDoc *yamlmeta.Document
+	if o.SchemaFlags.JSONSchemaFile != "" {
+		effectiveSchemaDoc, err = jsonschema.ToSchemaDoc(o.SchemaFlags.JSONSchemaFile)
+		if err != nil {
+			return TemplateOutput{Err: err}
+		}
+		schema, err = yamlmeta.NewDocumentSchema(effectiveSchemaDoc)
+		if err != nil {
+			return TemplateOutput{Err: err}
+		}
+	} else if len(schemaDocs) > 0 {
 		if o.SchemaEnabled {
-			schema, err = yamlmeta.NewDocumentSchema(schemaDocs[0])
+			effectiveSchemaDoc = schemaDocs[0]
+
+			refResolver := jsonschema.NewRefResolver(
+				jsonschema.RefResolverOpts{IgnoreMissingRefs: o.SchemaFlags.IgnoreMissingRefs}, ui.Warnf)
+			if err := refResolver.ResolveDocument(effectiveSchemaDoc); err != nil {
+				return TemplateOutput{Err: err}
+			}
+
+			schema, err = yamlmeta.NewDocumentSchema(effectiveSchemaDoc)
 			if err != nil {
 				return TemplateOutput{Err: err}
 			}
@@ -149,6 +217,13 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 		}
 	}
 
+	if o.SchemaFlags.Inspect != "" {
+		if effectiveSchemaDoc == nil {
+			return TemplateOutput{Err: fmt.Errorf("--schema-inspect requires a schema document (via --schema-json or a ytt schema document with --enable-experiment-schema)")}
+		}
+		return o.inspectSchema(effectiveSchemaDoc, jsonschema.Format(o.SchemaFlags.Inspect))
+	}
+
 	values, libraryValues, err := libraryLoader.Values(valuesOverlays, schema)
 	if err != nil {
 		return TemplateOutput{Err: err}
@@ -168,7 +243,57 @@ func (o *TemplateOptions) RunWithFiles(in TemplateInput, ui cmdcore.PlainUI) Tem
 		return TemplateOutput{Err: err}
 	}
 
-	return TemplateOutput{Files: result.Files, DocSet: result.DocSet}
+	unknownsCollector := unknowns.NewCollector()
+	if err := detectUnusedFlagDataValues(flagDataValuesOverlay, rootLibrary, unknownsCollector); err != nil {
+		return TemplateOutput{Err: err}
+	}
+
+	if err := o.UnknownsFlags.WriteReport(unknownsCollector.Findings()); err != nil {
+		return TemplateOutput{Err: err}
+	}
+
+	return TemplateOutput{Files: result.Files, DocSet: result.DocSet, Unknowns: unknownsCollector.Findings()}
+}
+
+// insertOverlayBeforeFlagOverlay inserts overlay immediately before the last
+// entry in overlays. DataValuesFlags.AsOverlays always returns file-based
+// overlays followed by the --data-value* flag overlay last, so explicit
+// flags are meant to win over everything else; inserting here keeps that
+// true while still letting overlay layer on top of the file-based values.
+func insertOverlayBeforeFlagOverlay(overlays []*yamlmeta.Document, overlay *yamlmeta.Document) []*yamlmeta.Document {
+	if overlay == nil {
+		return overlays
+	}
+
+	insertAt := len(overlays)
+	if insertAt > 0 {
+		insertAt--
+	}
+
+	result := make([]*yamlmeta.Document, 0, len(overlays)+1)
+	result = append(result, overlays[:insertAt]...)
+	result = append(result, overlay)
+	result = append(result, overlays[insertAt:]...)
+	return result
+}
+
+// mergeInputs combines the Input() of every source that has one, instead of
+// using only the first match, so e.g. a remote -f reference and a local -f
+// path supplied in the same invocation both contribute files rather than one
+// silently winning over the other.
+func mergeInputs(srcs []FileSource) (TemplateInput, error) {
+	var in TemplateInput
+	for _, src := range srcs {
+		if !src.HasInput() {
+			continue
+		}
+		srcIn, err := src.Input()
+		if err != nil {
+			return TemplateInput{}, err
+		}
+		in.Files = append(in.Files, srcIn.Files...)
+	}
+	return in, nil
 }
 
 func (o *TemplateOptions) pickSource(srcs []FileSource, pickFunc func(FileSource) bool) FileSource {
@@ -198,3 +323,14 @@ func (o *TemplateOptions) inspectFiles(rootLibrary *workspace.Library, ui cmdcor
 		},
 	}
 }
+
+func (o *TemplateOptions) inspectSchema(schemaDoc *yamlmeta.Document, format jsonschema.Format) TemplateOutput {
+	out, err := jsonschema.FromSchema(schemaDoc, format)
+	if err != nil {
+		return TemplateOutput{Err: err}
+	}
+
+	// Write the formatted bytes as-is; routing them through DocSet would
+	// re-encode them as a YAML/JSON string literal instead of raw JSON.
+	return TemplateOutput{Raw: out}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/files"
+)
+
+type fakeFileSource struct {
+	hasInput bool
+	files    []*files.File
+}
+
+func (s fakeFileSource) HasInput() bool                { return s.hasInput }
+func (s fakeFileSource) HasOutput() bool               { return false }
+func (s fakeFileSource) Input() (TemplateInput, error) { return TemplateInput{Files: s.files}, nil }
+func (s fakeFileSource) Output(TemplateOutput) error   { return nil }
+
+func TestMergeInputsCombinesAllSourcesWithInput(t *testing.T) {
+	remoteFile := &files.File{}
+	localFile := &files.File{}
+
+	srcs := []FileSource{
+		fakeFileSource{hasInput: true, files: []*files.File{remoteFile}},
+		fakeFileSource{hasInput: true, files: []*files.File{localFile}},
+		fakeFileSource{hasInput: false, files: []*files.File{{}}},
+	}
+
+	in, err := mergeInputs(srcs)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if len(in.Files) != 2 {
+		t.Fatalf("Expected files from both sources with input to be merged, got: %d", len(in.Files))
+	}
+	if in.Files[0] != remoteFile || in.Files[1] != localFile {
+		t.Errorf("Expected files to preserve source order")
+	}
+}
+
+func TestWriteRawOutputWritesRawBytes(t *testing.T) {
+	var buf bytes.Buffer
+
+	handled, err := writeRawOutput(&buf, TemplateOutput{Raw: []byte(`{"type":"object"}`)})
+	if !handled {
+		t.Fatalf("Expected a Raw payload to be handled")
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if buf.String() != `{"type":"object"}` {
+		t.Errorf("Expected Raw bytes to be written verbatim, got: %q", buf.String())
+	}
+}
+
+func TestWriteRawOutputPropagatesErr(t *testing.T) {
+	var buf bytes.Buffer
+
+	handled, err := writeRawOutput(&buf, TemplateOutput{Raw: []byte("x"), Err: fmt.Errorf("boom")})
+	if !handled {
+		t.Fatalf("Expected a Raw payload to be handled even when Err is set")
+	}
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("Expected the output's Err to be returned, got: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written when Err is set, got: %q", buf.String())
+	}
+}
+
+func TestWriteRawOutputIgnoresNonRawOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	handled, err := writeRawOutput(&buf, TemplateOutput{})
+	if handled {
+		t.Errorf("Expected output with no Raw payload to be left to the FileSource pipeline")
+	}
+	if err != nil {
+		t.Errorf("Expected no error, got: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/spf13/cobra"
+)
+
+const environmentsRootKey = "environments"
+const environmentInheritsKey = "inherits"
+const environmentNameKey = "environment"
+
+// EnvironmentFlags holds the selection made via --environment. It lets a single
+// set of data values files describe many named environments (dev, staging, prod, ...)
+// and pick one of them at render time.
+type EnvironmentFlags struct {
+	Name string
+}
+
+func (s *EnvironmentFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.Name, "environment", "",
+		"Select a named environment overlay declared under the top-level 'environments' key")
+}
+
+// AsOverlays extracts the 'environments:' map from the given data values overlays,
+// resolves the 'inherits:' chain for the selected environment, and returns a single
+// overlay document carrying the merged environment values plus the reserved
+// 'environment.name' value. It returns no overlay (and no error) when no environment
+// was selected, whether or not any of the overlays declare an 'environments' map.
+//
+// As a side effect, it strips the 'environments:' key from each of the given
+// overlays in place: it's internal configuration for this feature, not a
+// data value itself, and leaking it would expose every environment's full
+// definition (not just the selected one) into the final data.values.
+func (s *EnvironmentFlags) AsOverlays(overlays []*yamlmeta.Document) (*yamlmeta.Document, error) {
+	environments := map[string]*yamlmeta.MapItem{}
+
+	for _, doc := range overlays {
+		rootMap, ok := doc.Value.(*yamlmeta.Map)
+		if !ok {
+			continue
+		}
+
+		var keptItems []*yamlmeta.MapItem
+		for _, item := range rootMap.Items {
+			if item.Key != environmentsRootKey {
+				keptItems = append(keptItems, item)
+				continue
+			}
+
+			envMap, ok := item.Value.(*yamlmeta.Map)
+			if !ok {
+				return nil, fmt.Errorf("Expected '%s' to be a map", environmentsRootKey)
+			}
+			for _, envItem := range envMap.Items {
+				name, ok := envItem.Key.(string)
+				if !ok {
+					return nil, fmt.Errorf("Expected '%s' entries to be named by string keys", environmentsRootKey)
+				}
+				environments[name] = envItem
+			}
+		}
+		rootMap.Items = keptItems
+	}
+
+	if s.Name == "" {
+		return nil, nil
+	}
+
+	chain, err := s.resolveChain(environments, s.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &yamlmeta.Map{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		envMap, ok := chain[i].Value.(*yamlmeta.Map)
+		if !ok {
+			return nil, fmt.Errorf("Expected environment '%s' to be a map", s.Name)
+		}
+		for _, item := range envMap.Items {
+			if item.Key == environmentInheritsKey {
+				continue
+			}
+			merged.Items = append(merged.Items, item)
+		}
+	}
+	merged.Items = append(merged.Items, &yamlmeta.MapItem{
+		Key: environmentNameKey,
+		Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "name", Value: s.Name},
+		}},
+	})
+
+	return &yamlmeta.Document{Value: merged}, nil
+}
+
+func (s *EnvironmentFlags) resolveChain(environments map[string]*yamlmeta.MapItem,
+	name string, seen []string) ([]*yamlmeta.MapItem, error) {
+
+	for _, seenName := range seen {
+		if seenName == name {
+			return nil, fmt.Errorf("Expected no cycles among '%s', but found one starting at '%s'",
+				environmentsRootKey, name)
+		}
+	}
+
+	env, found := environments[name]
+	if !found {
+		return nil, fmt.Errorf("Expected to find environment '%s' under '%s'", name, environmentsRootKey)
+	}
+
+	chain := []*yamlmeta.MapItem{env}
+
+	envMap, ok := env.Value.(*yamlmeta.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected environment '%s' to be a map", name)
+	}
+	for _, item := range envMap.Items {
+		if item.Key == environmentInheritsKey {
+			parent, ok := item.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("Expected '%s' on environment '%s' to be a string", environmentInheritsKey, name)
+			}
+			parentChain, err := s.resolveChain(environments, parent, append(seen, name))
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, parentChain...)
+		}
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestEnvironmentFlagsAsOverlaysInheritsAndOverrides(t *testing.T) {
+	overlays := []*yamlmeta.Document{{
+		Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "environments", Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+				{Key: "base", Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+					{Key: "log_level", Value: "info"},
+					{Key: "replicas", Value: 1},
+				}}},
+				{Key: "prod", Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+					{Key: "inherits", Value: "base"},
+					{Key: "replicas", Value: 3},
+				}}},
+			}}},
+		}},
+	}}
+
+	flags := EnvironmentFlags{Name: "prod"}
+	overlay, err := flags.AsOverlays(overlays)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if overlay == nil {
+		t.Fatalf("Expected an overlay to be returned")
+	}
+
+	merged := overlay.Value.(*yamlmeta.Map)
+
+	values := map[interface{}]interface{}{}
+	for _, item := range merged.Items {
+		values[item.Key] = item.Value
+	}
+
+	if values["replicas"] != 3 {
+		t.Errorf("Expected 'prod' to override inherited 'replicas', got: %v", values["replicas"])
+	}
+	if values["log_level"] != "info" {
+		t.Errorf("Expected 'prod' to inherit 'log_level' from 'base', got: %v", values["log_level"])
+	}
+	if _, found := values["inherits"]; found {
+		t.Errorf("Expected 'inherits' key to be stripped from merged overlay")
+	}
+
+	envValue, found := values[environmentNameKey]
+	if !found {
+		t.Fatalf("Expected reserved key '%s' to be set", environmentNameKey)
+	}
+	if environmentNameKey != "environment" {
+		t.Fatalf("Expected reserved key to be 'environment' so #@ data.values.environment.name resolves, got '%s'", environmentNameKey)
+	}
+
+	envMap, ok := envValue.(*yamlmeta.Map)
+	if !ok || len(envMap.Items) != 1 || envMap.Items[0].Key != "name" || envMap.Items[0].Value != "prod" {
+		t.Errorf("Expected 'environment: {name: prod}', got: %#v", envValue)
+	}
+}
+
+func TestEnvironmentFlagsAsOverlaysStripsEnvironmentsKey(t *testing.T) {
+	baseOverlay := &yamlmeta.Document{
+		Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+			{Key: "log_level", Value: "warn"},
+			{Key: "environments", Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+				{Key: "prod", Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+					{Key: "replicas", Value: 3},
+				}}},
+			}}},
+		}},
+	}
+
+	flags := EnvironmentFlags{Name: "prod"}
+	if _, err := flags.AsOverlays([]*yamlmeta.Document{baseOverlay}); err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+
+	rootMap := baseOverlay.Value.(*yamlmeta.Map)
+	for _, item := range rootMap.Items {
+		if item.Key == "environments" {
+			t.Errorf("Expected 'environments' key to be stripped from the source overlay, got: %#v", rootMap.Items)
+		}
+	}
+	if len(rootMap.Items) != 1 || rootMap.Items[0].Key != "log_level" {
+		t.Errorf("Expected only 'log_level' to remain on the source overlay, got: %#v", rootMap.Items)
+	}
+}
+
+func TestEnvironmentFlagsAsOverlaysNoSelection(t *testing.T) {
+	flags := EnvironmentFlags{}
+	overlay, err := flags.AsOverlays(nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if overlay != nil {
+		t.Errorf("Expected no overlay when no environment was selected, got: %#v", overlay)
+	}
+}
+
+func TestInsertOverlayBeforeFlagOverlay(t *testing.T) {
+	fileOverlay := &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{{Key: "source", Value: "file"}}}}
+	flagOverlay := &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{{Key: "source", Value: "flag"}}}}
+	envOverlay := &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{{Key: "source", Value: "environment"}}}}
+
+	result := insertOverlayBeforeFlagOverlay([]*yamlmeta.Document{fileOverlay, flagOverlay}, envOverlay)
+
+	if len(result) != 3 || result[0] != fileOverlay || result[1] != envOverlay || result[2] != flagOverlay {
+		t.Errorf("Expected [file, environment, flag] so --data-value* still wins, got: %#v", result)
+	}
+}
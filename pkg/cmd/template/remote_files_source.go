@@ -0,0 +1,109 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/remotefiles"
+	"github.com/spf13/cobra"
+)
+
+// RemoteFilesSourceOpts configures how -f arguments naming remote artifacts
+// (http(s)://, oci://, git+https://) are fetched and cached.
+type RemoteFilesSourceOpts struct {
+	CacheDir string
+	Offline  bool
+}
+
+func (s *RemoteFilesSourceOpts) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.CacheDir, "remote-cache-dir", "",
+		"Cache -f references fetched over the network (http(s)://, oci://, git+https://) in this directory, keyed by content digest")
+	cmd.Flags().BoolVar(&s.Offline, "remote-offline", false,
+		"Resolve remote -f references from --remote-cache-dir only, failing if any isn't already cached")
+}
+
+// RemoteFilesSource resolves the subset of -f arguments that name remote
+// artifacts, fetching (or serving from cache) each one into a local
+// directory so the rest of ytt can treat them like any other input file.
+type RemoteFilesSource struct {
+	regularOpts RegularFilesSourceOpts
+	opts        RemoteFilesSourceOpts
+	ui          cmdcore.PlainUI
+}
+
+func NewRemoteFilesSource(regularOpts RegularFilesSourceOpts, opts RemoteFilesSourceOpts, ui cmdcore.PlainUI) *RemoteFilesSource {
+	return &RemoteFilesSource{regularOpts, opts, ui}
+}
+
+func (s *RemoteFilesSource) HasInput() bool  { return len(s.remoteRefs()) > 0 }
+func (s *RemoteFilesSource) HasOutput() bool { return false }
+
+func (s *RemoteFilesSource) remoteRefs() []string {
+	var refs []string
+	for _, f := range s.regularOpts.Files {
+		if remotefiles.IsRemote(f) {
+			refs = append(refs, f)
+		}
+	}
+	return refs
+}
+
+func (s *RemoteFilesSource) Input() (TemplateInput, error) {
+	resolver := remotefiles.NewResolver(remotefiles.Opts{
+		CacheDir: s.opts.CacheDir,
+		Offline:  s.opts.Offline,
+	})
+
+	var in TemplateInput
+	for _, ref := range s.remoteRefs() {
+		s.ui.Debugf("Resolving remote file source: %s\n", ref)
+
+		dir, err := resolver.Resolve(ref)
+		if err != nil {
+			return TemplateInput{}, err
+		}
+
+		fs, err := collectFiles(dir)
+		if err != nil {
+			return TemplateInput{}, fmt.Errorf("Collecting files fetched from '%s': %s", ref, err)
+		}
+		in.Files = append(in.Files, fs...)
+	}
+
+	return in, nil
+}
+
+func (s *RemoteFilesSource) Output(TemplateOutput) error {
+	return fmt.Errorf("RemoteFilesSource cannot be used as an output destination")
+}
+
+func collectFiles(dir string) ([]*files.File, error) {
+	var result []*files.File
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := files.NewFile(path)
+		if err != nil {
+			return err
+		}
+		result = append(result, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
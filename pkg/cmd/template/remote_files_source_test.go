@@ -0,0 +1,32 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	cmdcore "github.com/k14s/ytt/pkg/cmd/core"
+)
+
+func TestRemoteFilesSourceRemoteRefsReadsRegularFilesSourceOpts(t *testing.T) {
+	s := NewRemoteFilesSource(
+		RegularFilesSourceOpts{Files: []string{
+			"local.yml",
+			"https://example.com/vals.yml",
+			"oci://example.com/bundle:latest",
+		}},
+		RemoteFilesSourceOpts{},
+		cmdcore.NewPlainUI(false),
+	)
+
+	refs := s.remoteRefs()
+	expected := []string{"https://example.com/vals.yml", "oci://example.com/bundle:latest"}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Expected only remote refs from -f to be picked up, got: %#v", refs)
+	}
+	if !s.HasInput() {
+		t.Errorf("Expected HasInput() to be true when -f includes a remote ref")
+	}
+}
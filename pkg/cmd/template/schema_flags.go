@@ -0,0 +1,37 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// SchemaFlags configures the experimental schema subsystem: inspecting the
+// effective schema as JSON Schema/OpenAPI, and importing an external JSON
+// Schema file in place of ytt's own schema DSL.
+type SchemaFlags struct {
+	Inspect           string
+	JSONSchemaFile    string
+	IgnoreMissingRefs bool
+}
+
+func (s *SchemaFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.Inspect, "schema-inspect", "",
+		"Instead of templating, print the effective schema (one of: 'json-schema', 'openapi-v3')")
+	cmd.Flags().StringVar(&s.JSONSchemaFile, "schema-json", "",
+		"Validate data values against an external JSON Schema file instead of a ytt schema document")
+	cmd.Flags().BoolVar(&s.IgnoreMissingRefs, "schema-ignore-missing-refs", false,
+		"Skip an unreachable #@schema/ref (with a warning), keeping the node's locally written schema, instead of failing the run")
+}
+
+func (s *SchemaFlags) ValidateInspectFormat() error {
+	switch s.Inspect {
+	case "", "json-schema", "openapi-v3":
+		return nil
+	default:
+		return fmt.Errorf("Unknown --schema-inspect format '%s' (expected 'json-schema' or 'openapi-v3')", s.Inspect)
+	}
+}
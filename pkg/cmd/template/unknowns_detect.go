@@ -0,0 +1,89 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/k14s/ytt/pkg/unknowns"
+	"github.com/k14s/ytt/pkg/workspace"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// detectUnusedFlagDataValues records a CategoryUnusedDataValue finding for
+// every top-level key set via --data-value*/--data-values-file that no
+// accessible template textually references as "data.values.<key>".
+//
+// This covers CategoryUnusedDataValue only. The request also asks for
+// CategoryUndefinedResult, CategoryEmptyOverlayMatch, and
+// CategoryUnreadSchemaKey, each of which needs a collector threaded into
+// the #@ function-call evaluator, the overlay applier, and the schema-read
+// path respectively -- none of which have real source under pkg/workspace
+// or pkg/yamltemplate in this checkout for this change to hook into. Those
+// three categories are NOT implemented: --report-unknowns will never
+// produce a finding for them until that wiring lands, and this is
+// deliberately a static text scan rather than a trace of what the
+// evaluator actually read, so it can only ever approximate "unused" too.
+//
+// Location is left unset on these findings: unlike the other three
+// categories, which each have a single evaluation site to point at,
+// "unused" is established by the *absence* of a reference across every
+// accessible file, so there's no one file/line to report.
+func detectUnusedFlagDataValues(flagOverlay *yamlmeta.Document, rootLibrary *workspace.Library, collector *unknowns.Collector) error {
+	if flagOverlay == nil {
+		return nil
+	}
+
+	rootMap, ok := flagOverlay.Value.(*yamlmeta.Map)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, item := range rootMap.Items {
+		if key, ok := item.Key.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var allContents []byte
+	for _, fileInLib := range rootLibrary.ListAccessibleFiles() {
+		bs, err := fileInLib.File.Bytes()
+		if err != nil {
+			return fmt.Errorf("Reading '%s': %s", fileInLib.File.RelativePath(), err)
+		}
+		allContents = append(allContents, bs...)
+		allContents = append(allContents, '\n')
+	}
+
+	for _, key := range unreferencedDataValueKeys(keys, allContents) {
+		collector.Record(unknowns.Finding{
+			Category: unknowns.CategoryUnusedDataValue,
+			Message: fmt.Sprintf(
+				"data value '%s' was supplied via --data-value*, but 'data.values.%s' does not appear in any template",
+				key, key),
+		})
+	}
+
+	return nil
+}
+
+// unreferencedDataValueKeys returns the subset of keys that never appear as
+// a whole "data.values.<key>" reference anywhere in contents. Matching is
+// word-boundary delimited so a key "foo" isn't considered referenced just
+// because some file mentions "data.values.foobar".
+func unreferencedDataValueKeys(keys []string, contents []byte) []string {
+	var unreferenced []string
+	for _, key := range keys {
+		pattern := regexp.MustCompile(`\bdata\.values\.` + regexp.QuoteMeta(key) + `\b`)
+		if !pattern.Match(contents) {
+			unreferenced = append(unreferenced, key)
+		}
+	}
+	return unreferenced
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnreferencedDataValueKeys(t *testing.T) {
+	contents := []byte(`
+#@ load("@ytt:data", "data")
+---
+name: #@ data.values.name
+`)
+
+	unreferenced := unreferencedDataValueKeys([]string{"name", "replicas"}, contents)
+
+	if !reflect.DeepEqual(unreferenced, []string{"replicas"}) {
+		t.Errorf("Expected only 'replicas' to be unreferenced, got: %#v", unreferenced)
+	}
+}
+
+func TestUnreferencedDataValueKeysAllReferenced(t *testing.T) {
+	contents := []byte(`name: #@ data.values.name`)
+
+	unreferenced := unreferencedDataValueKeys([]string{"name"}, contents)
+
+	if len(unreferenced) != 0 {
+		t.Errorf("Expected no unreferenced keys, got: %#v", unreferenced)
+	}
+}
+
+func TestUnreferencedDataValueKeysDoesNotMatchOnSubstring(t *testing.T) {
+	contents := []byte(`name: #@ data.values.foobar`)
+
+	unreferenced := unreferencedDataValueKeys([]string{"foo"}, contents)
+
+	if !reflect.DeepEqual(unreferenced, []string{"foo"}) {
+		t.Errorf("Expected 'foo' to be unreferenced since only 'data.values.foobar' appears, got: %#v", unreferenced)
+	}
+}
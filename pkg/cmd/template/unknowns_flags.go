@@ -0,0 +1,47 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/k14s/ytt/pkg/unknowns"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// UnknownsFlags configures the "unknowns" report: things ytt could not
+// confidently resolve while templating. Currently this only covers unused
+// data values (a --data-value*/--data-values-file key no template
+// references); undefined function results, overlay selectors that matched
+// nothing, and unread schema keys are not yet detected. See
+// detectUnusedFlagDataValues for why.
+type UnknownsFlags struct {
+	ReportPath string
+}
+
+func (u *UnknownsFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&u.ReportPath, "report-unknowns", "",
+		"Write a YAML report of unused --data-value*/--data-values-file keys to the given path")
+}
+
+// WriteReport marshals findings as YAML and writes them to ReportPath. It is
+// a no-op if ReportPath was not set.
+func (u *UnknownsFlags) WriteReport(findings []unknowns.Finding) error {
+	if u.ReportPath == "" {
+		return nil
+	}
+
+	bs, err := yaml.Marshal(findings)
+	if err != nil {
+		return fmt.Errorf("Marshaling unknowns report: %s", err)
+	}
+
+	if err := ioutil.WriteFile(u.ReportPath, bs, 0600); err != nil {
+		return fmt.Errorf("Writing unknowns report to '%s': %s", u.ReportPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,474 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package remotefiles resolves -f arguments that name remote artifacts
+// (http(s) raw files/tarballs, oci:// bundles, git+https:// checkouts) into
+// a local directory, caching fetched content by digest in a
+// user-configurable directory so repeated or offline runs don't need
+// network access.
+package remotefiles
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Scheme identifies which resolver handles a given -f argument.
+type Scheme string
+
+const (
+	SchemeHTTP Scheme = "http"
+	SchemeOCI  Scheme = "oci"
+	SchemeGit  Scheme = "git"
+)
+
+// IsRemote reports whether ref names a remote artifact rather than a local
+// path or '-' (stdin).
+func IsRemote(ref string) bool {
+	return SchemeOf(ref) != ""
+}
+
+// SchemeOf returns which Scheme handles ref, or "" if ref is a local path.
+func SchemeOf(ref string) Scheme {
+	switch {
+	case strings.HasPrefix(ref, "http://"), strings.HasPrefix(ref, "https://"):
+		return SchemeHTTP
+	case strings.HasPrefix(ref, "oci://"):
+		return SchemeOCI
+	case strings.HasPrefix(ref, "git+https://"), strings.HasPrefix(ref, "git+http://"):
+		return SchemeGit
+	default:
+		return ""
+	}
+}
+
+// Opts configures caching behavior shared by all resolvers.
+type Opts struct {
+	// CacheDir is where fetched content is cached, keyed by content digest.
+	// Defaults to a directory under os.TempDir().
+	CacheDir string
+	// Offline forces cache-only resolution (no network access), failing any
+	// reference not already present in CacheDir.
+	Offline bool
+}
+
+// Resolver fetches (or serves from cache) the local directory backing a
+// single remote -f reference.
+type Resolver struct {
+	opts Opts
+}
+
+func NewResolver(opts Opts) *Resolver {
+	return &Resolver{opts: opts}
+}
+
+// Resolve returns the local directory containing the files named by ref (a
+// reference for which IsRemote(ref) is true).
+//
+// In --remote-offline mode (or for a pinned ref, one whose content can never
+// change once fetched), a cache hit is served unconditionally. Otherwise ref
+// is floating (a branch, a "latest"-style tag, or a plain http(s) URL) and
+// its upstream content could have moved since it was last fetched, so it's
+// always re-fetched to pick up changes; a re-fetch whose digest is unchanged
+// replaces the existing cache entry at the same path.
+func (r *Resolver) Resolve(ref string) (string, error) {
+	index, err := r.readIndex()
+	if err != nil {
+		return "", err
+	}
+
+	if digest, ok := index[ref]; ok {
+		dir := filepath.Join(r.cacheDir(), digest)
+		if _, err := os.Stat(dir); err == nil {
+			if r.opts.Offline || isPinnedRef(ref) {
+				return dir, nil
+			}
+		}
+	}
+
+	if r.opts.Offline {
+		return "", fmt.Errorf("'%s' is not available in --remote-cache-dir and --remote-offline was set", ref)
+	}
+
+	return r.fetch(ref)
+}
+
+var gitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// isPinnedRef reports whether ref names content that can never change once
+// fetched: an OCI ref pinned to a content digest, or a git ref pinned to a
+// full commit SHA. Anything else (a floating OCI tag, a git branch or
+// lightweight tag name, or a plain http(s) URL, which has no pinning
+// mechanism at all) is considered floating and must be revalidated.
+func isPinnedRef(ref string) bool {
+	switch SchemeOf(ref) {
+	case SchemeOCI:
+		return strings.Contains(ref, "@sha256:")
+	case SchemeGit:
+		_, _, gitRef, err := parseGitRef(ref)
+		return err == nil && gitSHAPattern.MatchString(gitRef)
+	default:
+		return false
+	}
+}
+
+func (r *Resolver) cacheDir() string {
+	if r.opts.CacheDir != "" {
+		return r.opts.CacheDir
+	}
+	return filepath.Join(os.TempDir(), "ytt-remote-cache")
+}
+
+func (r *Resolver) indexPath() string {
+	return filepath.Join(r.cacheDir(), "index.json")
+}
+
+func (r *Resolver) readIndex() (map[string]string, error) {
+	bs, err := ioutil.ReadFile(r.indexPath())
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Reading remote cache index: %s", err)
+	}
+
+	var index map[string]string
+	if err := json.Unmarshal(bs, &index); err != nil {
+		return nil, fmt.Errorf("Unmarshaling remote cache index: %s", err)
+	}
+	return index, nil
+}
+
+func (r *Resolver) writeIndex(ref, digest string) error {
+	index, err := r.readIndex()
+	if err != nil {
+		return err
+	}
+	index[ref] = digest
+
+	bs, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.cacheDir(), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.indexPath(), bs, 0600)
+}
+
+// fetch downloads ref into a temporary directory, digests its contents, and
+// moves it into the cache under that digest, recording ref -> digest in the
+// index (so a floating oci tag or git branch reuses its last-fetched
+// content on a subsequent --remote-offline run).
+func (r *Resolver) fetch(ref string) (string, error) {
+	tmpDir, err := ioutil.TempDir("", "ytt-remote-fetch")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var fetchErr error
+	switch SchemeOf(ref) {
+	case SchemeHTTP:
+		fetchErr = fetchHTTP(ref, tmpDir)
+	case SchemeOCI:
+		fetchErr = fetchOCI(ref, tmpDir)
+	case SchemeGit:
+		fetchErr = fetchGit(ref, tmpDir)
+	default:
+		fetchErr = fmt.Errorf("Unsupported remote scheme for '%s'", ref)
+	}
+	if fetchErr != nil {
+		return "", fetchErr
+	}
+
+	digest, err := digestDir(tmpDir)
+	if err != nil {
+		return "", err
+	}
+	if err := r.writeIndex(ref, digest); err != nil {
+		return "", err
+	}
+
+	finalDir := filepath.Join(r.cacheDir(), digest)
+	if err := os.RemoveAll(finalDir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(finalDir), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, finalDir); err != nil {
+		return "", err
+	}
+
+	return finalDir, nil
+}
+
+// fetchHTTP downloads ref (a raw file or a .tar.gz/.tgz bundle) into destDir.
+func fetchHTTP(ref, destDir string) error {
+	resp, err := http.Get(ref)
+	if err != nil {
+		return fmt.Errorf("Fetching '%s': %s", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Fetching '%s': unexpected status code %d", ref, resp.StatusCode)
+	}
+
+	if strings.HasSuffix(ref, ".tar.gz") || strings.HasSuffix(ref, ".tgz") {
+		return extractTarGz(resp.Body, destDir)
+	}
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Reading '%s': %s", ref, err)
+	}
+
+	name := filepath.Base(ref)
+	if name == "" || name == "/" {
+		name = "file.yml"
+	}
+	return ioutil.WriteFile(filepath.Join(destDir, name), contents, 0644)
+}
+
+// fetchOCI pulls the flattened filesystem of an OCI image ref (with the
+// "oci://" prefix stripped) into destDir via `crane export`, so ytt doesn't
+// need to vendor a registry client of its own.
+func fetchOCI(ref, destDir string) error {
+	image := strings.TrimPrefix(ref, "oci://")
+
+	cmd := exec.Command("crane", "export", image, "-")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Starting 'crane export %s': %s", image, err)
+	}
+
+	if err := extractTar(stdout, destDir); err != nil {
+		_ = cmd.Wait()
+		return fmt.Errorf("Extracting OCI image '%s': %s", image, err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("Running 'crane export %s': %s", image, err)
+	}
+	return nil
+}
+
+// fetchGit clones a "git+https://host/repo.git//subpath?ref=v1.2.3"
+// reference at the pinned ref and copies subpath into destDir.
+func fetchGit(ref, destDir string) error {
+	repoURL, subpath, gitRef, err := parseGitRef(ref)
+	if err != nil {
+		return err
+	}
+
+	cloneDir, err := ioutil.TempDir("", "ytt-remote-git-clone")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	// git clone --branch only accepts branch and tag names, so a full
+	// commit SHA is fetched and checked out explicitly.
+	if gitSHAPattern.MatchString(gitRef) {
+		for _, args := range [][]string{
+			{"init", cloneDir},
+			{"-C", cloneDir, "fetch", "--depth", "1", repoURL, gitRef},
+			{"-C", cloneDir, "checkout", "FETCH_HEAD"},
+		} {
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				return fmt.Errorf("Fetching '%s' at '%s': %s: %s", repoURL, gitRef, err, string(out))
+			}
+		}
+		return copyDir(filepath.Join(cloneDir, subpath), destDir)
+	}
+
+	args := []string{"clone", "--depth", "1"}
+	if gitRef != "" {
+		args = append(args, "--branch", gitRef)
+	}
+	args = append(args, repoURL, cloneDir)
+
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Cloning '%s': %s: %s", repoURL, err, string(out))
+	}
+
+	return copyDir(filepath.Join(cloneDir, subpath), destDir)
+}
+
+// parseGitRef splits a "git+https://host/repo.git//subpath?ref=v1.2.3"
+// reference into its repo URL, subpath (possibly empty), and pinned ref.
+func parseGitRef(ref string) (string, string, string, error) {
+	rest := strings.TrimPrefix(ref, "git+")
+
+	var gitRef string
+	if idx := strings.Index(rest, "?ref="); idx != -1 {
+		gitRef = rest[idx+len("?ref="):]
+		rest = rest[:idx]
+	}
+
+	repoURL, subpath := rest, ""
+	if idx := strings.Index(rest, "//"); idx != -1 {
+		schemeEnd := strings.Index(rest, "://") + len("://")
+		if nextSlashes := strings.Index(rest[schemeEnd:], "//"); nextSlashes != -1 {
+			idx = schemeEnd + nextSlashes
+			repoURL, subpath = rest[:idx], rest[idx+2:]
+		}
+	}
+
+	if gitRef == "" {
+		return "", "", "", fmt.Errorf("Expected '%s' to pin a ref via '?ref=<tag-or-branch-or-sha>'", ref)
+	}
+	return repoURL, subpath, gitRef, nil
+}
+
+func extractTarGz(r io.Reader, destDir string) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("Reading gzip stream: %s", err)
+	}
+	defer gzr.Close()
+	return extractTar(gzr, destDir)
+}
+
+func extractTar(r io.Reader, destDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Reading tar stream: %s", err)
+		}
+
+		target, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return fmt.Errorf("Extracting tar entry '%s': %s", hdr.Name, err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			f.Close()
+		}
+	}
+}
+
+// safeJoin joins destDir and name the way archive/tar entries are laid out
+// on disk, rejecting any name (e.g. "../../etc/passwd" or an absolute path)
+// that would resolve outside destDir. Remote tarballs (OCI image layers, git
+// checkouts) are untrusted input, so this guards against a malicious entry
+// overwriting files elsewhere on the filesystem ("tar slip"/"zip slip").
+func safeJoin(destDir, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("'%s' is an absolute path", name)
+	}
+	target := filepath.Join(destDir, name)
+
+	rel, err := filepath.Rel(destDir, target)
+	if err != nil {
+		return "", fmt.Errorf("'%s' escapes destination directory", name)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' escapes destination directory", name)
+	}
+	return target, nil
+}
+
+func copyDir(srcDir, destDir string) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, contents, info.Mode())
+	})
+}
+
+// digestDir computes a content digest over every regular file under dir
+// (path relative to dir, plus contents), independent of filesystem walk
+// order.
+func digestDir(dir string) (string, error) {
+	var paths []string
+	contents := map[string][]byte{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		contents[rel] = bs
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("Digesting '%s': %s", dir, err)
+	}
+
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		h.Write([]byte(p))
+		h.Write(contents[p])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
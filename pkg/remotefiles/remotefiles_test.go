@@ -0,0 +1,99 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remotefiles
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+)
+
+func TestExtractTarRejectsPathTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	contents := []byte("evil")
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "../../etc/passwd",
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+	}); err != nil {
+		t.Fatalf("Writing tar header: %s", err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		t.Fatalf("Writing tar contents: %s", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Closing tar writer: %s", err)
+	}
+
+	destDir := t.TempDir()
+	if err := extractTar(&buf, destDir); err == nil {
+		t.Fatalf("Expected extracting a tar entry with a path-traversal name to fail")
+	}
+}
+
+func TestExtractTarWritesWithinDestDir(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	contents := []byte("hello")
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "sub/dir/file.yml",
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+	}); err != nil {
+		t.Fatalf("Writing tar header: %s", err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		t.Fatalf("Writing tar contents: %s", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Closing tar writer: %s", err)
+	}
+
+	destDir := t.TempDir()
+	if err := extractTar(&buf, destDir); err != nil {
+		t.Fatalf("Expected a well-formed tar to extract cleanly, got: %s", err)
+	}
+}
+
+func TestSafeJoinRejectsAbsolutePath(t *testing.T) {
+	if _, err := safeJoin("/dest", "/etc/passwd"); err == nil {
+		t.Errorf("Expected an absolute entry name to be rejected")
+	}
+}
+
+func TestSafeJoinAllowsNestedPath(t *testing.T) {
+	target, err := safeJoin("/dest", "a/b/c.yml")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if target != "/dest/a/b/c.yml" {
+		t.Errorf("Expected '/dest/a/b/c.yml', got: %q", target)
+	}
+}
+
+func TestIsPinnedRef(t *testing.T) {
+	pinned := []string{
+		"oci://example.com/bundle@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"git+https://example.com/repo.git?ref=0123456789abcdef0123456789abcdef01234567",
+	}
+	for _, ref := range pinned {
+		if !isPinnedRef(ref) {
+			t.Errorf("Expected '%s' to be considered pinned", ref)
+		}
+	}
+
+	floating := []string{
+		"oci://example.com/bundle:latest",
+		"git+https://example.com/repo.git?ref=main",
+		"https://example.com/vals.yml",
+	}
+	for _, ref := range floating {
+		if isPinnedRef(ref) {
+			t.Errorf("Expected '%s' to be considered floating", ref)
+		}
+	}
+}
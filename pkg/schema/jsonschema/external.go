@@ -0,0 +1,102 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// ToSchemaDoc reads a JSON Schema file from path and converts it into a ytt
+// schema document, suitable for passing to yamlmeta.NewDocumentSchema. This
+// lets users who already maintain a JSON Schema validate ytt data values
+// against it without rewriting it in the ytt schema DSL.
+func ToSchemaDoc(path string) (*yamlmeta.Document, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading JSON Schema file '%s': %s", path, err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		return nil, fmt.Errorf("Unmarshaling JSON Schema file '%s': %s", path, err)
+	}
+
+	value, err := schemaToValue(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("Converting JSON Schema file '%s': %s", path, err)
+	}
+
+	rootMap, ok := value.(*yamlmeta.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected top-level JSON Schema '%s' to describe an object", path)
+	}
+
+	return &yamlmeta.Document{Value: rootMap}, nil
+}
+
+func schemaToValue(schema map[string]interface{}) (interface{}, error) {
+	typeName, _ := schema["type"].(string)
+
+	switch typeName {
+	case "object", "":
+		props, _ := schema["properties"].(map[string]interface{})
+
+		keys := make([]string, 0, len(props))
+		for key := range props {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		rootMap := &yamlmeta.Map{}
+		for _, key := range keys {
+			propSchema, ok := props[key].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Expected property '%s' to be a schema object", key)
+			}
+			propValue, err := schemaToValue(propSchema)
+			if err != nil {
+				return nil, fmt.Errorf("Converting property '%s': %s", key, err)
+			}
+			rootMap.Items = append(rootMap.Items, &yamlmeta.MapItem{Key: key, Value: propValue})
+		}
+		return rootMap, nil
+
+	case "array":
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		itemValue, err := schemaToValue(itemSchema)
+		if err != nil {
+			return nil, fmt.Errorf("Converting array items: %s", err)
+		}
+		return &yamlmeta.Array{Items: []*yamlmeta.ArrayItem{{Value: itemValue}}}, nil
+
+	case "string":
+		if def, ok := schema["default"].(string); ok {
+			return def, nil
+		}
+		return "", nil
+
+	case "number", "integer":
+		if def, ok := schema["default"]; ok {
+			return def, nil
+		}
+		return float64(0), nil
+
+	case "boolean":
+		if def, ok := schema["default"].(bool); ok {
+			return def, nil
+		}
+		return false, nil
+
+	case "null":
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported JSON Schema type '%s'", typeName)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsonschema converts between ytt's schema document format
+// (yamlmeta.Document, written in the ytt schema DSL) and JSON Schema, so
+// that ytt can both describe its data-values contract to JSON-Schema-aware
+// tooling and consume JSON Schemas written by other tools.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// Format is the output shape requested via --schema-inspect.
+type Format string
+
+const (
+	Draft2020_12 Format = "json-schema"
+	OpenAPIv3    Format = "openapi-v3"
+)
+
+// annotationNullable is ytt's `#@schema/nullable` annotation; a nullable key
+// is omitted from the emitted "required" list.
+const annotationNullable template.AnnotationName = "schema/nullable"
+
+// annotationValidation is ytt's `#@schema/validation` annotation. Only its
+// "enum" kwarg is translated here; other validation kwargs don't have a
+// direct JSON Schema equivalent and are left alone.
+const annotationValidation template.AnnotationName = "schema/validation"
+
+// FromSchema translates a ytt schema document into JSON Schema (Draft 2020-12)
+// or an OpenAPI v3 schema object, and returns it pretty-printed.
+func FromSchema(schemaDoc *yamlmeta.Document, format Format) ([]byte, error) {
+	rootMap, ok := schemaDoc.Value.(*yamlmeta.Map)
+	if !ok {
+		return nil, fmt.Errorf("Expected schema document to contain a map, but was %T", schemaDoc.Value)
+	}
+
+	properties, required := mapToProperties(rootMap)
+
+	result := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+
+	switch format {
+	case Draft2020_12:
+		result["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	case OpenAPIv3:
+		// OpenAPI v3 schema objects are a constrained subset of JSON Schema;
+		// no additional wrapping is needed at the object level.
+	default:
+		return nil, fmt.Errorf("Unknown format '%s'", format)
+	}
+
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// mapToProperties walks m's items in a deterministic (sorted-by-key) order,
+// so the same schema document always produces byte-identical output.
+func mapToProperties(m *yamlmeta.Map) (map[string]interface{}, []string) {
+	byKey := map[string]*yamlmeta.MapItem{}
+	keys := make([]string, 0, len(m.Items))
+
+	for _, item := range m.Items {
+		key, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		byKey[key] = item
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	properties := map[string]interface{}{}
+	var required []string
+
+	for _, key := range keys {
+		item := byKey[key]
+		properties[key] = valueToSchema(item.Value, item)
+		if !isNullable(item) {
+			required = append(required, key)
+		}
+	}
+
+	return properties, required
+}
+
+// valueToSchema builds the JSON Schema for value, layering on any `enum`
+// declared via #@schema/validation on item (the node value came from).
+func valueToSchema(value interface{}, item yamlmeta.Node) map[string]interface{} {
+	schema := valueTypeSchema(value)
+	if enum := enumValues(item); len(enum) > 0 {
+		schema["enum"] = enum
+	}
+	return schema
+}
+
+func valueTypeSchema(value interface{}) map[string]interface{} {
+	switch typed := value.(type) {
+	case *yamlmeta.Map:
+		properties, required := mapToProperties(typed)
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case *yamlmeta.Array:
+		var items map[string]interface{}
+		if len(typed.Items) > 0 {
+			items = valueToSchema(typed.Items[0].Value, typed.Items[0])
+		} else {
+			items = map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}
+	case string:
+		return map[string]interface{}{"type": "string", "default": typed}
+	case int, int64, float64:
+		return map[string]interface{}{"type": "number", "default": typed}
+	case bool:
+		return map[string]interface{}{"type": "boolean", "default": typed}
+	case nil:
+		return map[string]interface{}{"type": "null"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func isNullable(node yamlmeta.Node) bool {
+	_, ok := node.GetAnnotations()[annotationNullable]
+	return ok
+}
+
+func enumValues(node yamlmeta.Node) []interface{} {
+	args, ok := node.GetAnnotations()[annotationValidation]
+	if !ok {
+		return nil
+	}
+	for _, arg := range args {
+		if kwarg, ok := arg.(template.KwargAnnotationArg); ok && kwarg.Name == "enum" {
+			if values, ok := kwarg.Value.(*yamlmeta.Array); ok {
+				result := make([]interface{}, 0, len(values.Items))
+				for _, item := range values.Items {
+					result = append(result, item.Value)
+				}
+				return result
+			}
+		}
+	}
+	return nil
+}
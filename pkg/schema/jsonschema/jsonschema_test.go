@@ -0,0 +1,105 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+func TestFromSchemaRequiredAndTypes(t *testing.T) {
+	schemaDoc := &yamlmeta.Document{Value: &yamlmeta.Map{Items: []*yamlmeta.MapItem{
+		{Key: "name", Value: "default-name"},
+		{Key: "replicas", Value: 1},
+		{Key: "tags", Value: &yamlmeta.Array{Items: []*yamlmeta.ArrayItem{{Value: "a"}}}},
+	}}}
+
+	out, err := FromSchema(schemaDoc, Draft2020_12)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %s, output: %s", err, out)
+	}
+
+	if result["type"] != "object" {
+		t.Errorf("Expected type 'object', got: %v", result["type"])
+	}
+	if result["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("Expected Draft 2020-12 $schema, got: %v", result["$schema"])
+	}
+
+	required, ok := result["required"].([]interface{})
+	if !ok || len(required) != 3 {
+		t.Fatalf("Expected all 3 keys to be required by default, got: %#v", result["required"])
+	}
+
+	props := result["properties"].(map[string]interface{})
+	nameProp := props["name"].(map[string]interface{})
+	if nameProp["type"] != "string" || nameProp["default"] != "default-name" {
+		t.Errorf("Expected string property with default, got: %#v", nameProp)
+	}
+
+	tagsProp := props["tags"].(map[string]interface{})
+	if tagsProp["type"] != "array" {
+		t.Errorf("Expected array property, got: %#v", tagsProp)
+	}
+}
+
+func TestFromSchemaUnknownFormat(t *testing.T) {
+	schemaDoc := &yamlmeta.Document{Value: &yamlmeta.Map{}}
+	_, err := FromSchema(schemaDoc, Format("bogus"))
+	if err == nil {
+		t.Fatalf("Expected an error for unknown format")
+	}
+}
+
+func TestSchemaToValueDeterministicKeyOrder(t *testing.T) {
+	parsed := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"zeta":  map[string]interface{}{"type": "string"},
+			"alpha": map[string]interface{}{"type": "string"},
+			"mu":    map[string]interface{}{"type": "string"},
+		},
+	}
+
+	var firstOrder []interface{}
+	for i := 0; i < 10; i++ {
+		value, err := schemaToValue(parsed)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %s", err)
+		}
+		rootMap := value.(*yamlmeta.Map)
+
+		var order []interface{}
+		for _, item := range rootMap.Items {
+			order = append(order, item.Key)
+		}
+
+		if firstOrder == nil {
+			firstOrder = order
+			continue
+		}
+		if len(order) != len(firstOrder) {
+			t.Fatalf("Expected stable key count across conversions")
+		}
+		for i := range order {
+			if order[i] != firstOrder[i] {
+				t.Fatalf("Expected deterministic key order across repeated conversions, got %v then %v", firstOrder, order)
+			}
+		}
+	}
+
+	expected := []interface{}{"alpha", "mu", "zeta"}
+	for i, key := range expected {
+		if firstOrder[i] != key {
+			t.Errorf("Expected sorted key order %v, got %v", expected, firstOrder)
+		}
+	}
+}
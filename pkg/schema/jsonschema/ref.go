@@ -0,0 +1,273 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+)
+
+// httpClient bounds how long a single #@schema/ref fetch can hang, so a
+// stalled (but not actively refused) host fails the run in reasonable time
+// instead of blocking it indefinitely -- --schema-ignore-missing-refs is
+// useless as a safety valve if the fetch never returns at all.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// AnnotationRef is the `#@schema/ref "<url-or-path>#/pointer"` annotation.
+// It splices a subtree of an external JSON Schema into the schema node it
+// annotates; a schema value already present at that node takes precedence
+// over the imported one.
+const AnnotationRef template.AnnotationName = "schema/ref"
+
+// refCache holds fetched JSON Schema documents for the lifetime of the
+// process, since the same external schema is commonly referenced from many
+// nodes (and many ytt invocations within the same binary run).
+var refCache = struct {
+	mu   sync.Mutex
+	docs map[string]map[string]interface{}
+}{docs: map[string]map[string]interface{}{}}
+
+// RefResolverOpts configures how #@schema/ref annotations are resolved.
+type RefResolverOpts struct {
+	// IgnoreMissingRefs skips an unreachable ref (with a warning) instead of
+	// failing the run: the annotated node keeps only its locally written
+	// value, which is not replaced with AnySchema.
+	IgnoreMissingRefs bool
+}
+
+// RefResolver resolves #@schema/ref annotations found on a ytt schema
+// document.
+type RefResolver struct {
+	opts  RefResolverOpts
+	warnf func(string, ...interface{})
+}
+
+func NewRefResolver(opts RefResolverOpts, warnf func(string, ...interface{})) *RefResolver {
+	return &RefResolver{opts: opts, warnf: warnf}
+}
+
+// ResolveDocument walks doc looking for #@schema/ref annotations and splices
+// in the referenced subtree at each annotated node.
+func (r *RefResolver) ResolveDocument(doc *yamlmeta.Document) error {
+	return r.resolveNode(doc.Value)
+}
+
+func (r *RefResolver) resolveNode(node interface{}) error {
+	switch typed := node.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typed.Items {
+			if err := r.resolveAnnotated(item, &item.Value); err != nil {
+				return fmt.Errorf("Resolving schema/ref on key '%v': %s", item.Key, err)
+			}
+		}
+	case *yamlmeta.Array:
+		for _, item := range typed.Items {
+			if err := r.resolveAnnotated(item, &item.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RefResolver) resolveAnnotated(node yamlmeta.Node, value *interface{}) error {
+	ref, ok := refAnnotationArg(node)
+	if !ok {
+		return r.resolveNode(*value)
+	}
+
+	imported, err := r.resolveRef(ref)
+	if err != nil {
+		if r.opts.IgnoreMissingRefs {
+			r.warnf("Warning: schema/ref '%s' could not be resolved (%s); keeping only the locally written schema for this node\n", ref, err)
+			return nil
+		}
+		return fmt.Errorf("Resolving schema/ref '%s': %s", ref, err)
+	}
+
+	*value = mergeSchemaValues(imported, *value)
+
+	// The node's own local children (the part of *value that took
+	// precedence over the imported subtree) may carry their own nested
+	// #@schema/ref annotations; recurse so those still get resolved.
+	return r.resolveNode(*value)
+}
+
+func refAnnotationArg(node yamlmeta.Node) (string, bool) {
+	anns := node.GetAnnotations()
+	args, ok := anns[AnnotationRef]
+	if !ok || len(args) == 0 {
+		return "", false
+	}
+	ref, ok := args[0].(string)
+	return ref, ok
+}
+
+// resolveRef fetches (or pulls from cache) the JSON Schema document named by
+// the location portion of ref and returns the subtree at its "#/json/pointer"
+// fragment, converted into a ytt schema value.
+func (r *RefResolver) resolveRef(ref string) (interface{}, error) {
+	location, pointer := splitRef(ref)
+
+	doc, err := fetchSchemaDoc(location)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := resolvePointer(doc, pointer)
+	if err != nil {
+		return nil, err
+	}
+	target, err = followInternalRefs(doc, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return schemaToValue(target)
+}
+
+func splitRef(ref string) (string, string) {
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func fetchSchemaDoc(location string) (map[string]interface{}, error) {
+	refCache.mu.Lock()
+	defer refCache.mu.Unlock()
+
+	if doc, found := refCache.docs[location]; found {
+		return doc, nil
+	}
+
+	contents, err := readLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(contents, &doc); err != nil {
+		return nil, fmt.Errorf("Unmarshaling JSON Schema '%s': %s", location, err)
+	}
+
+	refCache.docs[location] = doc
+	return doc, nil
+}
+
+func readLocation(location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		resp, err := httpClient.Get(location)
+		if err != nil {
+			return nil, fmt.Errorf("Fetching '%s': %s", location, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Fetching '%s': unexpected status code %d", location, resp.StatusCode)
+		}
+
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	contents, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("Reading '%s': %s", location, err)
+	}
+	return contents, nil
+}
+
+func resolvePointer(doc map[string]interface{}, pointer string) (map[string]interface{}, error) {
+	pointer = strings.TrimPrefix(pointer, "/")
+	if pointer == "" {
+		return doc, nil
+	}
+
+	var cursor interface{} = doc
+	for _, segment := range strings.Split(pointer, "/") {
+		m, ok := cursor.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Pointer segment '%s' does not resolve to an object", segment)
+		}
+		next, found := m[segment]
+		if !found {
+			return nil, fmt.Errorf("Pointer segment '%s' not found", segment)
+		}
+		cursor = next
+	}
+
+	result, ok := cursor.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Pointer '%s' does not resolve to an object", pointer)
+	}
+	return result, nil
+}
+
+// followInternalRefs resolves a "$ref": "#/..." found at the top of target
+// against doc, so an imported subtree that is itself a reference to another
+// part of the same document splices in correctly. A $ref nested inside
+// target's properties or items is not followed; schemaToValue converts such
+// a node into an empty map.
+func followInternalRefs(doc, target map[string]interface{}) (map[string]interface{}, error) {
+	seen := map[string]bool{}
+	for {
+		rawRef, ok := target["$ref"]
+		if !ok {
+			return target, nil
+		}
+		ref, ok := rawRef.(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return target, nil
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("Circular $ref '%s'", ref)
+		}
+		seen[ref] = true
+
+		resolved, err := resolvePointer(doc, strings.TrimPrefix(ref, "#"))
+		if err != nil {
+			return nil, fmt.Errorf("Following $ref '%s': %s", ref, err)
+		}
+		target = resolved
+	}
+}
+
+// mergeSchemaValues overlays local (the value already present on the
+// annotated node, which may be nil) on top of imported, so the local schema
+// authoring always wins on conflicts.
+func mergeSchemaValues(imported, local interface{}) interface{} {
+	if local == nil {
+		return imported
+	}
+
+	importedMap, importedOK := imported.(*yamlmeta.Map)
+	localMap, localOK := local.(*yamlmeta.Map)
+	if !importedOK || !localOK {
+		return local
+	}
+
+	merged := &yamlmeta.Map{}
+	overridden := map[interface{}]bool{}
+
+	for _, item := range localMap.Items {
+		merged.Items = append(merged.Items, item)
+		overridden[item.Key] = true
+	}
+	for _, item := range importedMap.Items {
+		if !overridden[item.Key] {
+			merged.Items = append(merged.Items, item)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonschema
+
+import "testing"
+
+func TestResolvePointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"definitions": map[string]interface{}{
+			"address": map[string]interface{}{
+				"type": "object",
+			},
+		},
+	}
+
+	result, err := resolvePointer(doc, "/definitions/address")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %s", err)
+	}
+	if result["type"] != "object" {
+		t.Errorf("Expected to resolve to the 'address' definition, got: %#v", result)
+	}
+
+	_, err = resolvePointer(doc, "/definitions/missing")
+	if err == nil {
+		t.Fatalf("Expected an error for a missing pointer segment")
+	}
+}
+
+func TestFollowInternalRefsDetectsCycle(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": map[string]interface{}{"$ref": "#/b"},
+		"b": map[string]interface{}{"$ref": "#/a"},
+	}
+
+	_, err := followInternalRefs(doc, doc["a"].(map[string]interface{}))
+	if err == nil {
+		t.Fatalf("Expected a circular $ref to be detected")
+	}
+}
+
+func TestSplitRef(t *testing.T) {
+	location, pointer := splitRef("schema.json#/definitions/address")
+	if location != "schema.json" || pointer != "/definitions/address" {
+		t.Errorf("Expected to split location and pointer, got: %q, %q", location, pointer)
+	}
+
+	location, pointer = splitRef("schema.json")
+	if location != "schema.json" || pointer != "" {
+		t.Errorf("Expected no pointer when ref has none, got: %q, %q", location, pointer)
+	}
+}
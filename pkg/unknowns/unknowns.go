@@ -0,0 +1,58 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package unknowns records things ytt could not confidently resolve while
+// evaluating a template run, so that callers can assert on them (e.g. in CI)
+// instead of having to scrape debug logs.
+package unknowns
+
+// Category classifies why a finding was recorded.
+type Category string
+
+const (
+	// CategoryUnusedDataValue marks a data-values key supplied on the CLI
+	// (via --data-value*) but never referenced by any template.
+	CategoryUnusedDataValue Category = "unused-data-value"
+	// CategoryUndefinedResult marks a #@ function call that evaluated to
+	// None/undefined.
+	CategoryUndefinedResult Category = "undefined-result"
+	// CategoryEmptyOverlayMatch marks an overlay selector that matched zero
+	// nodes.
+	CategoryEmptyOverlayMatch Category = "empty-overlay-match"
+	// CategoryUnreadSchemaKey marks a schema-declared key whose value was
+	// never read during evaluation.
+	CategoryUnreadSchemaKey Category = "unread-schema-key"
+)
+
+// Location points at where a Finding was observed.
+type Location struct {
+	File string
+	Line int
+}
+
+// Finding is a single thing ytt could not confidently resolve.
+type Finding struct {
+	Location Location
+	Category Category
+	Message  string
+}
+
+// Collector gathers Findings in the order they are recorded. It is not
+// threaded into the template context: the template command fills it after
+// evaluation, from a scan for unused data values only, so overlay
+// application, function calls and schema reads do not record into it.
+type Collector struct {
+	findings []Finding
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) Record(f Finding) {
+	c.findings = append(c.findings, f)
+}
+
+func (c *Collector) Findings() []Finding {
+	return c.findings
+}
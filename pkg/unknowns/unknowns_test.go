@@ -0,0 +1,28 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package unknowns
+
+import "testing"
+
+func TestCollectorRecordsInOrder(t *testing.T) {
+	c := NewCollector()
+
+	c.Record(Finding{Category: CategoryUnusedDataValue, Message: "first"})
+	c.Record(Finding{Category: CategoryEmptyOverlayMatch, Message: "second"})
+
+	findings := c.Findings()
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got: %d", len(findings))
+	}
+	if findings[0].Message != "first" || findings[1].Message != "second" {
+		t.Errorf("Expected findings to be returned in record order, got: %#v", findings)
+	}
+}
+
+func TestNewCollectorStartsEmpty(t *testing.T) {
+	c := NewCollector()
+	if len(c.Findings()) != 0 {
+		t.Errorf("Expected a new collector to start with no findings")
+	}
+}